@@ -0,0 +1,30 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"strings"
+	"sync"
+)
+
+// caseFoldCache maps a *structSpec to a lowercased-name -> original-name
+// lookup table, built once per struct type and reused across decodes.
+var caseFoldCache sync.Map // map[*structSpec]map[string]string
+
+// caseFoldKey looks up name against ss's fields case-insensitively,
+// building and caching the fold table for ss on first use. It returns the
+// original field key and whether a match was found.
+func caseFoldKey(ss *structSpec, name string) (string, bool) {
+	cached, ok := caseFoldCache.Load(ss)
+	if !ok {
+		m := make(map[string]string, len(ss.m))
+		for k := range ss.m {
+			m[strings.ToLower(k)] = k
+		}
+		cached, _ = caseFoldCache.LoadOrStore(ss, m)
+	}
+	orig, ok := cached.(map[string]string)[strings.ToLower(name)]
+	return orig, ok
+}
@@ -0,0 +1,33 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeCaseInsensitiveFallback(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"Int": 1}`))
+	var out atype
+	if err := Unmarshal(s, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Int != 1 {
+		t.Errorf("Int = %d, want 1", out.Int)
+	}
+}
+
+func TestDecoderDisableCaseInsensitiveMatch(t *testing.T) {
+	dec := NewDecoder(NewScanner(strings.NewReader(`{"Int": 1}`)))
+	dec.DisableCaseInsensitiveMatch()
+	var out atype
+	if err := dec.Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Int != 0 {
+		t.Errorf("Int = %d, want 0 (unmatched with case folding disabled)", out.Int)
+	}
+}
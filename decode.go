@@ -5,37 +5,84 @@
 package json
 
 import (
+	"bytes"
+	"encoding"
 	"encoding/base64"
 	"errors"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
+// Unmarshaler is the interface implemented by types that can unmarshal a
+// JSON description of themselves. UnmarshalJSON receives the raw JSON text
+// for the value (object, array, string, number, bool or null) that it is
+// decoding and must copy it if it needs to retain it after the call
+// returns.
+type Unmarshaler interface {
+	UnmarshalJSON([]byte) error
+}
+
 // Unmarshal deserializes data from the scanner to value v. In the case of
 // struct values, only exported fields will be decoded. The lowercase field
 // name is used as the key for each exported field, but this behavior may be
 // changed using the respective field tag. The tag may also contain flags to
 // tweak the decoding behavior for the field.
 func Unmarshal(s *Scanner, v interface{}) error {
+	return UnmarshalWith(s, v, UnmarshalOptions{})
+}
+
+// UnmarshalOptions controls optional decoding behavior that goes beyond the
+// zero-value defaults used by Unmarshal.
+type UnmarshalOptions struct {
+	// UseNumber causes numbers in interface{} destinations to be decoded
+	// as a RawNumber, preserving the original text of the number, rather
+	// than being parsed as a float64. Fields or elements typed as
+	// RawNumber receive the number's text whether or not it is set, and it
+	// has no effect on fields with a concrete numeric type such as int or
+	// float64.
+	UseNumber bool
+
+	// Hooks is a chain of DecodeHookFunc tried, in order, on every string,
+	// number or bool value before kind dispatch, unless a type decoder
+	// registered for the destination type takes the value first. The
+	// first hook to return handled=true wins; if none do, decoding
+	// proceeds as usual.
+	Hooks []DecodeHookFunc
+}
+
+// DecodeHookFunc lets callers intercept decoding of scalar JSON values
+// before kind dispatch, to convert "weakly typed" input - numbers sent as
+// strings, dates sent as RFC3339 text, and the like - into the
+// destination type without writing a per-type Unmarshaler. raw is the
+// token text of the scalar value currently being decoded. Hooks only see
+// String, Number and Bool values; they are not consulted for Null, Object
+// or Array values. If handled is false, decode falls through to its
+// normal dispatch.
+type DecodeHookFunc func(from Kind, to reflect.Type, raw []byte) (out interface{}, handled bool, err error)
+
+// UnmarshalWith is like Unmarshal but allows non-default decoding behavior
+// to be selected via opts.
+func UnmarshalWith(s *Scanner, v interface{}, opts UnmarshalOptions) error {
 	value, ok := v.(reflect.Value)
 	if !ok {
 		value = reflect.ValueOf(v)
 		switch value.Kind() {
 		case reflect.Map:
 			if value.IsNil() {
-				return errors.New("map arg must not be nil")
+				return errNilMap
 			}
 		case reflect.Ptr:
 			if value.IsNil() {
-				return errors.New("pointer arg must not be nil")
+				return errNilPtr
 			}
 			value = value.Elem()
 		default:
-			return errors.New("arg must be pointer or map")
+			return errNotPtrOrMap
 		}
 	}
 
-	d := decoder{s}
+	d := decoder{s: s, opts: opts}
 	if !d.s.Scan() {
 		return d.s.Err()
 	}
@@ -48,8 +95,66 @@ func Unmarshal(s *Scanner, v interface{}) error {
 	return d.s.Err()
 }
 
+var (
+	errNilMap      = errors.New("map arg must not be nil")
+	errNilPtr      = errors.New("pointer arg must not be nil")
+	errNotPtrOrMap = errors.New("arg must be pointer or map")
+)
+
 type decoder struct {
-	s *Scanner
+	s    *Scanner
+	opts UnmarshalOptions
+
+	disallowUnknownFields   bool
+	disallowDuplicateFields bool
+	metadata                *Metadata
+	path                    []string
+
+	typeDecoders map[reflect.Type]externalDecoderFunc
+
+	disableCaseInsensitiveMatch bool
+}
+
+// lookupTypeDecoder returns the registered decoder for t, if any, checking
+// this call's own overrides before the global registry.
+func (d *decoder) lookupTypeDecoder(t reflect.Type) externalDecoderFunc {
+	if fn, ok := d.typeDecoders[t]; ok {
+		return fn
+	}
+	return globalTypeDecoders.lookup(t)
+}
+
+// fieldPath returns the dotted path of name under the struct fields
+// currently being decoded, e.g. "foo.bar.baz".
+func (d *decoder) fieldPath(name string) string {
+	if len(d.path) == 0 {
+		return name
+	}
+	return strings.Join(d.path, ".") + "." + name
+}
+
+// A RawNumber represents a JSON number literal as the text that produced
+// it, deferring conversion to a numeric type until the caller asks for
+// one. It is named RawNumber, rather than the Number some other JSON
+// packages use, because this package's Kind enum already has a constant
+// called Number. Decode into a RawNumber, or into an interface{} with
+// UnmarshalOptions.UseNumber set, to avoid the precision loss that comes
+// from always parsing numbers as float64.
+type RawNumber string
+
+// Int64 parses the number as an int64.
+func (n RawNumber) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// Float64 parses the number as a float64.
+func (n RawNumber) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// String returns the original text of the number.
+func (n RawNumber) String() string {
+	return string(n)
 }
 
 // A DecodeTypeError describes a JSON value that was not appropriate for a value of a specific Go type.
@@ -62,6 +167,28 @@ func (e *DecodeTypeError) Error() string {
 	return "cannot unmarshal " + e.Kind.String() + " into Go value of type " + e.Type.String()
 }
 
+// An UnknownFieldError reports an object key that did not match any field
+// of the destination struct, encountered while decoding with
+// Decoder.DisallowUnknownFields enabled.
+type UnknownFieldError struct {
+	Path string // dotted path of the unknown key, e.g. "foo.bar.baz"
+}
+
+func (e *UnknownFieldError) Error() string {
+	return "unknown field " + strconv.Quote(e.Path)
+}
+
+// A DuplicateFieldError reports an object key that appeared more than once
+// within a single object, encountered while decoding with
+// Decoder.DisallowDuplicateFields enabled.
+type DuplicateFieldError struct {
+	Path string // dotted path of the duplicated key, e.g. "foo.bar.baz"
+}
+
+func (e *DuplicateFieldError) Error() string {
+	return "duplicate field " + strconv.Quote(e.Path)
+}
+
 func (d *decoder) typeError(v reflect.Value) error {
 	err := &DecodeTypeError{
 		Kind: d.s.Kind(),
@@ -71,13 +198,61 @@ func (d *decoder) typeError(v reflect.Value) error {
 }
 
 func (d *decoder) decode(v reflect.Value) error {
+	// A registered type decoder takes precedence over the
+	// Unmarshaler/TextUnmarshaler interfaces, so look one up against the
+	// type v will resolve to once its pointer chain is allocated -
+	// otherwise a *time.Time field would always be intercepted by
+	// time.Time's own UnmarshalJSON before a decoder registered for
+	// time.Time ever got a look.
+	want := concreteType(v.Type())
+	if d.s.Kind() != Null {
+		if fn := d.lookupTypeDecoder(want); fn != nil {
+			return fn(d.s, allocateToward(v, want))
+		}
+	}
+
+	// Decode hooks take precedence over the Unmarshaler/TextUnmarshaler
+	// interfaces too, for the same reason: StringToTimeHook would never
+	// fire on a time.Time field if time.Time's own UnmarshalJSON (which
+	// only accepts RFC3339) got first look.
+	if len(d.opts.Hooks) > 0 && d.s.Kind() != Object && d.s.Kind() != Array && d.s.Kind() != Null {
+		pv := allocateToward(v, want)
+		handled, err := d.runHooks(pv)
+		if handled {
+			return err
+		}
+		v = pv
+	}
+
+	u, ut, pv := indirect(v, d.s.Kind() == Null)
+	if u != nil {
+		raw, err := d.rawValue()
+		if err != nil {
+			return err
+		}
+		return u.UnmarshalJSON(raw)
+	}
+
 	if d.s.Kind() == Null {
-		v.Set(reflect.Zero(v.Type()))
+		if pv.IsValid() {
+			pv.Set(reflect.Zero(pv.Type()))
+		}
 		return nil
 	}
 
-	v = indirect(v)
+	if ut != nil {
+		if d.s.Kind() != String {
+			return d.typeError(pv)
+		}
+		return ut.UnmarshalText(d.s.Value())
+	}
+
+	v = pv
 	typ := v.Type()
+	if fn := d.lookupTypeDecoder(typ); fn != nil {
+		return fn(d.s, v)
+	}
+
 	decoder, ok := typeDecoder[typ]
 	if !ok {
 		decoder, ok = kindDecoder[typ.Kind()]
@@ -88,23 +263,200 @@ func (d *decoder) decode(v reflect.Value) error {
 	return decoder(d, v)
 }
 
+// concreteType follows a chain of pointer types down to the element type
+// decode will ultimately operate on, without dereferencing any value. It
+// lets decode consult the type-decoder registry against the type a **T
+// destination resolves to, even before any of the pointers in the chain
+// have been allocated.
+func concreteType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// allocateToward walks v down through pointers, allocating as needed,
+// until it reaches a value of type want (as produced by
+// concreteType(v.Type())).
+func allocateToward(v reflect.Value, want reflect.Type) reflect.Value {
+	for v.Kind() == reflect.Ptr && v.Type() != want {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// scalarRaw returns the token text of the scalar value the scanner is
+// currently positioned on, for handing to a DecodeHookFunc.
+func (d *decoder) scalarRaw() []byte {
+	switch d.s.Kind() {
+	case Bool:
+		if d.s.BoolValue() {
+			return []byte("true")
+		}
+		return []byte("false")
+	case Null:
+		return []byte("null")
+	default:
+		return d.s.Value()
+	}
+}
+
+// runHooks walks d.opts.Hooks in order, stopping at the first one that
+// reports handled=true and assigning its result to v.
+func (d *decoder) runHooks(v reflect.Value) (handled bool, err error) {
+	from := d.s.Kind()
+	raw := d.scalarRaw()
+	for _, hook := range d.opts.Hooks {
+		out, ok, err := hook(from, v.Type(), raw)
+		if err != nil {
+			return true, err
+		}
+		if !ok {
+			continue
+		}
+		if out == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return true, nil
+		}
+		ov := reflect.ValueOf(out)
+		switch {
+		case ov.Type().AssignableTo(v.Type()):
+			v.Set(ov)
+		case ov.Type().ConvertibleTo(v.Type()):
+			v.Set(ov.Convert(v.Type()))
+		default:
+			return true, d.typeError(v)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
 // indirect walks down v allocating pointers as needed, until it gets to a
-// non-pointer.
-func indirect(v reflect.Value) reflect.Value {
+// non-pointer. Along the way, if it finds a value implementing Unmarshaler
+// or encoding.TextUnmarshaler, it stops and returns that interface instead.
+// If decodingNull is true, indirect stops at the first settable pointer
+// instead of allocating through it, since the caller only needs to zero it.
+func indirect(v reflect.Value, decodingNull bool) (Unmarshaler, encoding.TextUnmarshaler, reflect.Value) {
+	v0 := v
+	haveAddr := false
+
+	if v.Kind() != reflect.Ptr && v.Type().Name() != "" && v.CanAddr() {
+		haveAddr = true
+		v = v.Addr()
+	}
 	for {
 		if v.Kind() == reflect.Interface && !v.IsNil() {
-			v = v.Elem()
-			continue
+			e := v.Elem()
+			if e.Kind() == reflect.Ptr && !e.IsNil() && (!decodingNull || e.Elem().Kind() == reflect.Ptr) {
+				haveAddr = false
+				v = e
+				continue
+			}
 		}
+
 		if v.Kind() != reflect.Ptr {
 			break
 		}
+
+		if decodingNull && v.CanSet() {
+			break
+		}
+
+		if v.Elem().Kind() == reflect.Interface && v.Elem().Elem() == v {
+			v = v.Elem()
+			break
+		}
+
 		if v.IsNil() {
 			v.Set(reflect.New(v.Type().Elem()))
 		}
-		v = v.Elem()
+		if v.Type().NumMethod() > 0 && v.CanInterface() {
+			if u, ok := v.Interface().(Unmarshaler); ok {
+				return u, nil, reflect.Value{}
+			}
+			if !decodingNull {
+				if ut, ok := v.Interface().(encoding.TextUnmarshaler); ok {
+					return nil, ut, reflect.Value{}
+				}
+			}
+		}
+
+		if haveAddr {
+			v = v0
+			haveAddr = false
+		} else {
+			v = v.Elem()
+		}
 	}
-	return v
+	return nil, nil, v
+}
+
+// rawValue reassembles the raw JSON text of the value the scanner is
+// currently positioned on, re-scanning nested objects and arrays until
+// their closing brace or bracket has been consumed. It is used to hand the
+// original bytes of a value to an Unmarshaler.
+func (d *decoder) rawValue() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.appendRawValue(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *decoder) appendRawValue(buf *bytes.Buffer) error {
+	switch d.s.Kind() {
+	case Null:
+		buf.WriteString("null")
+	case Bool:
+		if d.s.BoolValue() {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case Number:
+		buf.Write(d.s.Value())
+	case String:
+		buf.WriteString(strconv.Quote(string(d.s.Value())))
+	case Object:
+		buf.WriteByte('{')
+		os := d.s.ObjectScanner()
+		for i := 0; os.Scan(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strconv.Quote(os.Name()))
+			buf.WriteString(`:`)
+			if err := d.appendRawValue(buf); err != nil {
+				return err
+			}
+		}
+		if err := d.s.Err(); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+	case Array:
+		buf.WriteByte('[')
+		as := d.s.ArrayScanner()
+		for i := 0; as.Scan(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := d.appendRawValue(buf); err != nil {
+				return err
+			}
+		}
+		if err := d.s.Err(); err != nil {
+			return err
+		}
+		buf.WriteByte(']')
+	default:
+		return d.typeError(reflect.ValueOf(new(interface{})).Elem())
+	}
+	return nil
 }
 
 func (d *decoder) decodeFloat(v reflect.Value) error {
@@ -170,6 +522,14 @@ func (d *decoder) decodeByteSlice(v reflect.Value) error {
 	return nil
 }
 
+func (d *decoder) decodeRawNumber(v reflect.Value) error {
+	if d.s.Kind() != Number {
+		return d.typeError(v)
+	}
+	v.SetString(string(d.s.Value()))
+	return nil
+}
+
 func (d *decoder) decodeBool(v reflect.Value) error {
 	if d.s.Kind() != Bool {
 		return d.typeError(v)
@@ -304,13 +664,47 @@ func (d *decoder) decodeStruct(v reflect.Value) error {
 	typ := v.Type()
 	ss := structSpecForType(typ)
 	os := d.s.ObjectScanner()
+	var seen map[string]bool
+	if d.disallowDuplicateFields {
+		seen = make(map[string]bool)
+	}
 	for os.Scan() {
-		if fs := ss.m[os.Name()]; fs != nil {
-			err := d.decode(v.FieldByIndex(fs.index))
-			if err != nil && savedErr == nil {
-				savedErr = err
+		name := os.Name()
+		if seen != nil {
+			if seen[name] {
+				if savedErr == nil {
+					savedErr = &DuplicateFieldError{Path: d.fieldPath(name)}
+				}
+				continue
+			}
+			seen[name] = true
+		}
+
+		fs := ss.m[name]
+		if fs == nil && !d.disableCaseInsensitiveMatch {
+			if orig, ok := caseFoldKey(ss, name); ok {
+				fs = ss.m[orig]
 			}
 		}
+		if fs == nil {
+			path := d.fieldPath(name)
+			if d.metadata != nil {
+				d.metadata.Unused = append(d.metadata.Unused, path)
+			} else if d.disallowUnknownFields && savedErr == nil {
+				savedErr = &UnknownFieldError{Path: path}
+			}
+			continue
+		}
+
+		if d.metadata != nil {
+			d.metadata.Keys = append(d.metadata.Keys, d.fieldPath(name))
+		}
+		d.path = append(d.path, name)
+		err := d.decode(v.FieldByIndex(fs.index))
+		d.path = d.path[:len(d.path)-1]
+		if err != nil && savedErr == nil {
+			savedErr = err
+		}
 	}
 	if err := d.s.Err(); err != nil {
 		return err
@@ -330,6 +724,9 @@ func (d *decoder) decodeInterface(v reflect.Value) error {
 func (d *decoder) decodeValueInterface() (interface{}, error) {
 	switch d.s.Kind() {
 	case Number:
+		if d.opts.UseNumber {
+			return RawNumber(d.s.Value()), nil
+		}
 		return strconv.ParseFloat(string(d.s.Value()), 64)
 	case String:
 		return string(d.s.Value()), nil
@@ -401,5 +798,6 @@ func init() {
 	typeDecoder = map[reflect.Type]decoderFunc{
 		reflect.TypeOf(make(map[string]interface{})): (*decoder).decodeMapStringInterface,
 		reflect.TypeOf(new(interface{})).Elem():      (*decoder).decodeInterface,
+		reflect.TypeOf(RawNumber("")):                (*decoder).decodeRawNumber,
 	}
 }
@@ -0,0 +1,135 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import "reflect"
+
+// A Decoder reads and decodes JSON values from a Scanner, with stricter
+// behavior than Unmarshal available on request. The zero value, once given
+// a Scanner via NewDecoder, behaves exactly like Unmarshal.
+type Decoder struct {
+	s    *Scanner
+	opts UnmarshalOptions
+
+	disallowUnknownFields       bool
+	disallowDuplicateFields     bool
+	metadata                    *Metadata
+	typeDecoders                map[reflect.Type]externalDecoderFunc
+	disableCaseInsensitiveMatch bool
+}
+
+// DisableCaseInsensitiveMatch turns off the case-insensitive fallback that
+// decodeStruct otherwise uses when an object key has no exact match among
+// a struct's field names, requiring an exact match instead.
+func (dec *Decoder) DisableCaseInsensitiveMatch() {
+	dec.disableCaseInsensitiveMatch = true
+}
+
+// NewDecoder returns a new Decoder that reads from s.
+func NewDecoder(s *Scanner) *Decoder {
+	return &Decoder{s: s}
+}
+
+// UseNumber causes Decode to store numbers found in interface{} positions
+// as a RawNumber instead of a float64. See UnmarshalOptions.UseNumber.
+func (dec *Decoder) UseNumber() {
+	dec.opts.UseNumber = true
+}
+
+// DisallowUnknownFields causes Decode to return an *UnknownFieldError when
+// the destination is a struct and an object key does not match any of its
+// fields, instead of silently ignoring the key.
+func (dec *Decoder) DisallowUnknownFields() {
+	dec.disallowUnknownFields = true
+}
+
+// DisallowDuplicateFields causes Decode to return a *DuplicateFieldError
+// when the same object key appears more than once within a single object
+// decoded into a struct. Objects decoded into a map or an interface{} are
+// not checked for duplicate keys.
+func (dec *Decoder) DisallowDuplicateFields() {
+	dec.disallowDuplicateFields = true
+}
+
+// Metadata records which object keys were decoded into struct fields and
+// which were ignored, mirroring the bookkeeping mapstructure does.
+type Metadata struct {
+	// Keys lists every object key, in dotted "a.b.c" path form, that was
+	// decoded into a destination struct field.
+	Keys []string
+
+	// Unused lists every object key, in dotted path form, that did not
+	// match any destination struct field.
+	Unused []string
+}
+
+// SetMetadata causes Decode to populate md with the keys it decodes and
+// ignores, rather than failing on unknown fields. It takes precedence over
+// DisallowUnknownFields, since md gives the caller the chance to audit
+// unused keys itself.
+func (dec *Decoder) SetMetadata(md *Metadata) {
+	dec.metadata = md
+}
+
+// Decode reads the next JSON value from the underlying Scanner into v,
+// honoring whatever strictness options have been set on dec.
+func (dec *Decoder) Decode(v interface{}) error {
+	value, ok := v.(reflect.Value)
+	if !ok {
+		value = reflect.ValueOf(v)
+		switch value.Kind() {
+		case reflect.Map:
+			if value.IsNil() {
+				return errNilMap
+			}
+		case reflect.Ptr:
+			if value.IsNil() {
+				return errNilPtr
+			}
+			value = value.Elem()
+		default:
+			return errNotPtrOrMap
+		}
+	}
+
+	d := dec.newDecoder()
+	if !d.s.Scan() {
+		return d.s.Err()
+	}
+
+	if err := d.decode(value); err != nil {
+		return err
+	}
+
+	d.s.Scan()
+	return d.s.Err()
+}
+
+// newDecoder builds the internal decoder carrying every option set on
+// dec, shared by Decode and UnmarshalStream.
+func (dec *Decoder) newDecoder() decoder {
+	return decoder{
+		s:                           dec.s,
+		opts:                        dec.opts,
+		disallowUnknownFields:       dec.disallowUnknownFields,
+		disallowDuplicateFields:     dec.disallowDuplicateFields,
+		metadata:                    dec.metadata,
+		typeDecoders:                dec.typeDecoders,
+		disableCaseInsensitiveMatch: dec.disableCaseInsensitiveMatch,
+	}
+}
+
+// UnmarshalStream is the streaming counterpart to Decode: it decodes the
+// top-level array dec's Scanner is positioned on one element at a time,
+// without retaining prior elements, while honoring every option set on
+// dec (UseNumber, DisallowUnknownFields, DisallowDuplicateFields,
+// Metadata, RegisterTypeDecoder and DisableCaseInsensitiveMatch). The
+// package-level UnmarshalStream cannot combine streaming with those
+// Decoder-scoped options, since it only has an UnmarshalOptions to work
+// with.
+func (dec *Decoder) UnmarshalStream(fn func(dec *ElementDecoder) error) error {
+	d := dec.newDecoder()
+	return unmarshalStream(&d, fn)
+}
@@ -0,0 +1,67 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecoderDisallowUnknownFields(t *testing.T) {
+	dec := NewDecoder(NewScanner(strings.NewReader(`{"int": 1, "bogus": 2}`)))
+	dec.DisallowUnknownFields()
+	var out atype
+	err := dec.Decode(&out)
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	ufe, ok := err.(*UnknownFieldError)
+	if !ok {
+		t.Fatalf("err = %#v, want *UnknownFieldError", err)
+	}
+	if ufe.Path != "bogus" {
+		t.Errorf("Path = %q, want %q", ufe.Path, "bogus")
+	}
+}
+
+func TestDecoderAllowsUnknownFieldsByDefault(t *testing.T) {
+	dec := NewDecoder(NewScanner(strings.NewReader(`{"int": 1, "bogus": 2}`)))
+	var out atype
+	if err := dec.Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Int != 1 {
+		t.Errorf("Int = %d, want 1", out.Int)
+	}
+}
+
+func TestDecoderDisallowDuplicateFields(t *testing.T) {
+	dec := NewDecoder(NewScanner(strings.NewReader(`{"int": 1, "int": 2}`)))
+	dec.DisallowDuplicateFields()
+	var out atype
+	err := dec.Decode(&out)
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if _, ok := err.(*DuplicateFieldError); !ok {
+		t.Fatalf("err = %#v, want *DuplicateFieldError", err)
+	}
+}
+
+func TestDecoderMetadata(t *testing.T) {
+	dec := NewDecoder(NewScanner(strings.NewReader(`{"int": 1, "bogus": 2}`)))
+	var md Metadata
+	dec.SetMetadata(&md)
+	var out atype
+	if err := dec.Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Keys) != 1 || md.Keys[0] != "int" {
+		t.Errorf("Keys = %v, want [int]", md.Keys)
+	}
+	if len(md.Unused) != 1 || md.Unused[0] != "bogus" {
+		t.Errorf("Unused = %v, want [bogus]", md.Unused)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"net"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	netIPType    = reflect.TypeOf(net.IP{})
+)
+
+// StringToTimeHook returns a DecodeHookFunc that parses string values
+// destined for a time.Time field using the given layouts, tried in order.
+// It defaults to time.RFC3339 if no layouts are given.
+func StringToTimeHook(layouts ...string) DecodeHookFunc {
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339}
+	}
+	return func(from Kind, to reflect.Type, raw []byte) (interface{}, bool, error) {
+		if from != String || to != timeType {
+			return nil, false, nil
+		}
+		s := string(raw)
+		var err error
+		for _, layout := range layouts {
+			var t time.Time
+			if t, err = time.Parse(layout, s); err == nil {
+				return t, true, nil
+			}
+		}
+		return nil, true, err
+	}
+}
+
+// StringToDurationHook is a DecodeHookFunc that parses string values
+// destined for a time.Duration field using time.ParseDuration.
+func StringToDurationHook(from Kind, to reflect.Type, raw []byte) (interface{}, bool, error) {
+	if from != String || to != durationType {
+		return nil, false, nil
+	}
+	d, err := time.ParseDuration(string(raw))
+	return d, true, err
+}
+
+// StringToNetIPHook is a DecodeHookFunc that parses string values destined
+// for a net.IP field using net.ParseIP.
+func StringToNetIPHook(from Kind, to reflect.Type, raw []byte) (interface{}, bool, error) {
+	if from != String || to != netIPType {
+		return nil, false, nil
+	}
+	ip := net.ParseIP(string(raw))
+	if ip == nil {
+		return nil, true, &DecodeTypeError{Kind: from, Type: to}
+	}
+	return ip, true, nil
+}
+
+// WeaklyTypedInputHook is a DecodeHookFunc mirroring mapstructure's
+// WeaklyTypedInput option: it coerces between strings, numbers and bools
+// rather than requiring the JSON value's kind to already match the
+// destination's.
+func WeaklyTypedInputHook(from Kind, to reflect.Type, raw []byte) (interface{}, bool, error) {
+	switch to.Kind() {
+	case reflect.String:
+		switch from {
+		case Number:
+			return string(raw), true, nil
+		case Bool:
+			return string(raw), true, nil
+		}
+	case reflect.Bool:
+		switch from {
+		case String:
+			b, err := strconv.ParseBool(string(raw))
+			return b, true, err
+		case Number:
+			f, err := strconv.ParseFloat(string(raw), 64)
+			if err != nil {
+				return nil, true, err
+			}
+			return f != 0, true, nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch from {
+		case String:
+			i, err := strconv.ParseInt(string(raw), 10, 64)
+			return i, true, err
+		case Bool:
+			if string(raw) == "true" {
+				return int64(1), true, nil
+			}
+			return int64(0), true, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch from {
+		case String:
+			u, err := strconv.ParseUint(string(raw), 10, 64)
+			return u, true, err
+		case Bool:
+			if string(raw) == "true" {
+				return uint64(1), true, nil
+			}
+			return uint64(0), true, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch from {
+		case String:
+			f, err := strconv.ParseFloat(string(raw), 64)
+			return f, true, err
+		case Bool:
+			if string(raw) == "true" {
+				return float64(1), true, nil
+			}
+			return float64(0), true, nil
+		}
+	}
+	return nil, false, nil
+}
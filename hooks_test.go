@@ -0,0 +1,138 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStringToTimeHookNonRFC3339(t *testing.T) {
+	// time.Time implements UnmarshalJSON itself, accepting only RFC3339.
+	// A hook for a different layout must still take effect instead of
+	// being shadowed by that interface.
+	s := NewScanner(strings.NewReader(`{"t": "2006-01-02"}`))
+	var out struct {
+		T time.Time
+	}
+	opts := UnmarshalOptions{Hooks: []DecodeHookFunc{StringToTimeHook("2006-01-02")}}
+	if err := UnmarshalWith(s, &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)
+	if !out.T.Equal(want) {
+		t.Errorf("T = %v, want %v", out.T, want)
+	}
+}
+
+func TestStringToDurationHook(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"d": "1h30m"}`))
+	var out struct {
+		D time.Duration
+	}
+	opts := UnmarshalOptions{Hooks: []DecodeHookFunc{StringToDurationHook}}
+	if err := UnmarshalWith(s, &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if out.D != 90*time.Minute {
+		t.Errorf("D = %v, want 1h30m", out.D)
+	}
+}
+
+func TestStringToNetIPHook(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"ip": "127.0.0.1"}`))
+	var out struct {
+		IP net.IP
+	}
+	opts := UnmarshalOptions{Hooks: []DecodeHookFunc{StringToNetIPHook}}
+	if err := UnmarshalWith(s, &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !out.IP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("IP = %v, want 127.0.0.1", out.IP)
+	}
+}
+
+func TestWeaklyTypedInputHook(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"n": "42", "b": "true"}`))
+	var out struct {
+		N int
+		B bool
+	}
+	opts := UnmarshalOptions{Hooks: []DecodeHookFunc{WeaklyTypedInputHook}}
+	if err := UnmarshalWith(s, &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if out.N != 42 {
+		t.Errorf("N = %d, want 42", out.N)
+	}
+	if !out.B {
+		t.Errorf("B = %v, want true", out.B)
+	}
+}
+
+func TestWeaklyTypedInputHookPreservesInt64Precision(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"n": "9223372036854775807", "u": "18446744073709551615"}`))
+	var out struct {
+		N int64
+		U uint64
+	}
+	opts := UnmarshalOptions{Hooks: []DecodeHookFunc{WeaklyTypedInputHook}}
+	if err := UnmarshalWith(s, &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if out.N != 9223372036854775807 {
+		t.Errorf("N = %d, want 9223372036854775807", out.N)
+	}
+	if out.U != 18446744073709551615 {
+		t.Errorf("U = %d, want 18446744073709551615", out.U)
+	}
+}
+
+func TestRunHooksHandlesNilOut(t *testing.T) {
+	hook := func(from Kind, to reflect.Type, raw []byte) (interface{}, bool, error) {
+		return nil, true, nil
+	}
+	s := NewScanner(strings.NewReader(`{"n": 42}`))
+	var out struct {
+		N int
+	}
+	out.N = 7
+	opts := UnmarshalOptions{Hooks: []DecodeHookFunc{hook}}
+	if err := UnmarshalWith(s, &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if out.N != 0 {
+		t.Errorf("N = %d, want 0 (zeroed by a hook that returned nil, true, nil)", out.N)
+	}
+}
+
+func TestHooksNotConsultedForObjectsAndArrays(t *testing.T) {
+	var sawKinds []Kind
+	hook := func(from Kind, to reflect.Type, raw []byte) (interface{}, bool, error) {
+		sawKinds = append(sawKinds, from)
+		return nil, false, nil
+	}
+	s := NewScanner(strings.NewReader(`{"inner": {"n": 1}, "list": [1, 2]}`))
+	var out struct {
+		Inner struct{ N int }
+		List  []int
+	}
+	opts := UnmarshalOptions{Hooks: []DecodeHookFunc{hook}}
+	if err := UnmarshalWith(s, &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range sawKinds {
+		if k == Object || k == Array {
+			t.Fatalf("hook was consulted for kind %v, want only scalar kinds", k)
+		}
+	}
+	if len(sawKinds) == 0 {
+		t.Fatal("hook was never consulted")
+	}
+}
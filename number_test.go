@@ -0,0 +1,70 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRawNumberMethods(t *testing.T) {
+	n := RawNumber("123")
+	if s := n.String(); s != "123" {
+		t.Errorf("String() = %q, want %q", s, "123")
+	}
+	i, err := n.Int64()
+	if err != nil || i != 123 {
+		t.Errorf("Int64() = %d, %v, want 123, nil", i, err)
+	}
+	f, err := n.Float64()
+	if err != nil || f != 123 {
+		t.Errorf("Float64() = %v, %v, want 123, nil", f, err)
+	}
+}
+
+func TestUnmarshalUseNumber(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"a": 12345678901234567890, "b": 1.5}`))
+	var out map[string]interface{}
+	err := UnmarshalWith(s, &out, UnmarshalOptions{UseNumber: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, ok := out["a"].(RawNumber)
+	if !ok {
+		t.Fatalf("a = %#v (%T), want RawNumber", out["a"], out["a"])
+	}
+	if a.String() != "12345678901234567890" {
+		t.Errorf("a = %q, want %q", a.String(), "12345678901234567890")
+	}
+	b, ok := out["b"].(RawNumber)
+	if !ok || b.String() != "1.5" {
+		t.Fatalf("b = %#v, want RawNumber(1.5)", out["b"])
+	}
+}
+
+func TestUnmarshalWithoutUseNumber(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"a": 1.5}`))
+	var out map[string]interface{}
+	if err := Unmarshal(s, &out); err != nil {
+		t.Fatal(err)
+	}
+	f, ok := out["a"].(float64)
+	if !ok || f != 1.5 {
+		t.Fatalf("a = %#v, want float64(1.5)", out["a"])
+	}
+}
+
+func TestUnmarshalNumberField(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"n": 42}`))
+	var out struct {
+		N RawNumber
+	}
+	if err := Unmarshal(s, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.N != RawNumber("42") {
+		t.Errorf("N = %q, want %q", out.N, "42")
+	}
+}
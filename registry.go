@@ -0,0 +1,89 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// An externalDecoderFunc is a user-supplied decoder registered via
+// RegisterTypeDecoder or Decoder.RegisterTypeDecoder. Unlike the internal
+// decoderFunc, it only needs the Scanner, since callers outside the
+// package have no use for the decoder struct.
+type externalDecoderFunc func(s *Scanner, v reflect.Value) error
+
+// typeDecoderRegistry is a copy-on-write map of externalDecoderFunc keyed
+// by reflect.Type, so that lookups on the decode hot path never take a
+// lock and registration (expected to be rare, typically at init time) pays
+// the cost of a full copy instead.
+type typeDecoderRegistry struct {
+	mu sync.Mutex // serializes writers; readers use the atomic.Value directly
+	m  atomic.Value
+}
+
+func newTypeDecoderRegistry() *typeDecoderRegistry {
+	r := &typeDecoderRegistry{}
+	r.m.Store(map[reflect.Type]externalDecoderFunc{})
+	return r
+}
+
+func (r *typeDecoderRegistry) load() map[reflect.Type]externalDecoderFunc {
+	return r.m.Load().(map[reflect.Type]externalDecoderFunc)
+}
+
+func (r *typeDecoderRegistry) register(t reflect.Type, fn externalDecoderFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.load()
+	next := make(map[reflect.Type]externalDecoderFunc, len(old)+1)
+	for k, v := range old {
+		next[k] = v
+	}
+	next[t] = fn
+	r.m.Store(next)
+}
+
+func (r *typeDecoderRegistry) lookup(t reflect.Type) externalDecoderFunc {
+	return r.load()[t]
+}
+
+var globalTypeDecoders = newTypeDecoderRegistry()
+
+// RegisterTypeDecoder teaches the package how to decode values of type t,
+// for domain types such as time.Time, uuid.UUID or decimal.Decimal that
+// have no natural mapping onto decodeSlice, decodeStruct and the other
+// kind-based decoders. It is meant to be called from init functions; fn is
+// looked up on every decode of a value of type t afterwards, so it should
+// not be registered per-request.
+//
+// When resolving how to decode a value, decode consults, in order: any
+// decoder registered for this call via Decoder.RegisterTypeDecoder, then
+// the global registry populated by RegisterTypeDecoder, then the
+// json.Unmarshaler/encoding.TextUnmarshaler interfaces, and finally the
+// built-in kind-based decoders. Registering a decoder for t also takes
+// effect for any number of pointers to t (*t, **t, ...), since decode
+// resolves the registry against the fully dereferenced type before
+// allocating the pointer chain.
+//
+// This package currently only implements decoding, so there is no
+// symmetrical RegisterTypeEncoder; add one alongside a Marshal/Encoder API
+// if this package grows an encode side.
+func RegisterTypeDecoder(t reflect.Type, fn func(s *Scanner, v reflect.Value) error) {
+	globalTypeDecoders.register(t, fn)
+}
+
+// RegisterTypeDecoder registers fn as a decoder for type t, scoped to this
+// Decoder only. It takes precedence over both the global registry
+// populated by the package-level RegisterTypeDecoder and the
+// json.Unmarshaler interface, letting a single call site override decoding
+// of a type without mutating global state.
+func (dec *Decoder) RegisterTypeDecoder(t reflect.Type, fn func(s *Scanner, v reflect.Value) error) {
+	if dec.typeDecoders == nil {
+		dec.typeDecoders = make(map[reflect.Type]externalDecoderFunc)
+	}
+	dec.typeDecoders[t] = fn
+}
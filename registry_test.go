@@ -0,0 +1,98 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+}
+
+func decodePointPair(s *Scanner, v reflect.Value) error {
+	if s.Kind() != String {
+		return &DecodeTypeError{Kind: s.Kind(), Type: v.Type()}
+	}
+	parts := strings.SplitN(string(s.Value()), ",", 2)
+	x, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return err
+	}
+	y, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(point{X: x, Y: y}).Convert(v.Type()))
+	return nil
+}
+
+func TestRegisterTypeDecoderGlobal(t *testing.T) {
+	RegisterTypeDecoder(reflect.TypeOf(point{}), decodePointPair)
+
+	s := NewScanner(strings.NewReader(`"3,4"`))
+	var p point
+	if err := Unmarshal(s, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p != (point{3, 4}) {
+		t.Errorf("p = %+v, want {3 4}", p)
+	}
+}
+
+func TestRegisterTypeDecoderGlobalThroughPointer(t *testing.T) {
+	RegisterTypeDecoder(reflect.TypeOf(point{}), decodePointPair)
+
+	s := NewScanner(strings.NewReader(`"5,6"`))
+	var pp *point
+	if err := Unmarshal(s, &pp); err != nil {
+		t.Fatal(err)
+	}
+	if pp == nil || *pp != (point{5, 6}) {
+		t.Errorf("pp = %v, want &{5 6}", pp)
+	}
+}
+
+func TestRegisterTypeDecoderGlobalThroughPointerNull(t *testing.T) {
+	RegisterTypeDecoder(reflect.TypeOf(point{}), decodePointPair)
+
+	s := NewScanner(strings.NewReader(`null`))
+	pp := &point{X: 1, Y: 2}
+	if err := Unmarshal(s, &pp); err != nil {
+		t.Fatal(err)
+	}
+	if pp != nil {
+		t.Errorf("pp = %v, want nil (registered type decoder must not see a null value)", pp)
+	}
+}
+
+func TestDecoderRegisterTypeDecoderPerCall(t *testing.T) {
+	// callPoint is never registered globally (unlike point, which the tests
+	// above register), so only the per-call registration can decode it.
+	type callPoint point
+
+	dec := NewDecoder(NewScanner(strings.NewReader(`"7,8"`)))
+	dec.RegisterTypeDecoder(reflect.TypeOf(callPoint{}), decodePointPair)
+
+	var p callPoint
+	if err := dec.Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p != (callPoint{7, 8}) {
+		t.Errorf("p = %+v, want {7 8}", p)
+	}
+
+	// The override is scoped to dec; a fresh Decoder without it should not
+	// pick up a type decoder that was only ever registered per-call.
+	dec2 := NewDecoder(NewScanner(strings.NewReader(`"9,10"`)))
+	var p2 callPoint
+	err := dec2.Decode(&p2)
+	if err == nil {
+		t.Fatal("want error decoding a string into a plain struct, got nil")
+	}
+}
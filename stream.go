@@ -0,0 +1,95 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import "reflect"
+
+// An ElementDecoder decodes a single element of the top-level array being
+// processed by UnmarshalStream. It is only valid for the duration of the
+// callback it was passed to.
+type ElementDecoder struct {
+	d *decoder
+}
+
+// Decode unmarshals the current array element into v, which must be a
+// non-nil pointer or map, exactly as with Unmarshal.
+func (ed *ElementDecoder) Decode(v interface{}) error {
+	value, ok := v.(reflect.Value)
+	if !ok {
+		value = reflect.ValueOf(v)
+		switch value.Kind() {
+		case reflect.Map:
+			if value.IsNil() {
+				return errNilMap
+			}
+		case reflect.Ptr:
+			if value.IsNil() {
+				return errNilPtr
+			}
+			value = value.Elem()
+		default:
+			return errNotPtrOrMap
+		}
+	}
+	return ed.d.decode(value)
+}
+
+// Skip discards the current array element without decoding it.
+func (ed *ElementDecoder) Skip() {
+	ed.d.rawValue()
+}
+
+// UnmarshalStream decodes the top-level array s is positioned on one
+// element at a time, calling fn once per element without retaining prior
+// elements. This is the idiomatic way to process arrays too large to
+// materialize in memory in one go, where decodeSlice's append-driven
+// growth would eventually exhaust it; see BenchmarkUnmarshalStream versus
+// BenchmarkDecodeSlice.
+//
+// UnmarshalStream only has an UnmarshalOptions to work with, so it cannot
+// honor Decoder-scoped options such as DisallowUnknownFields or
+// per-call RegisterTypeDecoder; use Decoder.UnmarshalStream for that.
+func UnmarshalStream(s *Scanner, fn func(dec *ElementDecoder) error) error {
+	return UnmarshalStreamWith(s, fn, UnmarshalOptions{})
+}
+
+// UnmarshalStreamWith is UnmarshalStream with non-default decoding
+// behavior selected via opts, mirroring UnmarshalWith.
+func UnmarshalStreamWith(s *Scanner, fn func(dec *ElementDecoder) error, opts UnmarshalOptions) error {
+	return unmarshalStream(&decoder{s: s, opts: opts}, fn)
+}
+
+// unmarshalStream is the shared implementation behind UnmarshalStreamWith
+// and Decoder.UnmarshalStream: it scans the array d.s is positioned on,
+// calling fn once per element via an ElementDecoder backed by d, so that
+// any strictness or registration options already set on d apply to every
+// element.
+func unmarshalStream(d *decoder, fn func(dec *ElementDecoder) error) error {
+	s := d.s
+	if !s.Scan() {
+		return s.Err()
+	}
+	if s.Kind() != Array {
+		return &DecodeTypeError{Kind: s.Kind(), Type: reflect.TypeOf([]interface{}(nil))}
+	}
+
+	ed := &ElementDecoder{d: d}
+	var savedErr error
+	as := s.ArrayScanner()
+	for as.Scan() {
+		if err := fn(ed); err != nil && savedErr == nil {
+			savedErr = err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if savedErr != nil {
+		return savedErr
+	}
+
+	s.Scan()
+	return s.Err()
+}
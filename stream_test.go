@@ -0,0 +1,142 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func bigArrayJSON(n int) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(i))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func TestUnmarshalStream(t *testing.T) {
+	var got []int
+	s := NewScanner(strings.NewReader(`[1, 2, 3]`))
+	err := UnmarshalStream(s, func(dec *ElementDecoder) error {
+		var x int
+		if err := dec.Decode(&x); err != nil {
+			return err
+		}
+		got = append(got, x)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestElementDecoderDecodeIntoMap(t *testing.T) {
+	var got []map[string]interface{}
+	s := NewScanner(strings.NewReader(`[{"a": 1}, {"b": 2}]`))
+	err := UnmarshalStream(s, func(dec *ElementDecoder) error {
+		m := make(map[string]interface{})
+		if err := dec.Decode(m); err != nil {
+			return err
+		}
+		got = append(got, m)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0]["a"] != float64(1) || got[1]["b"] != float64(2) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestDecoderUnmarshalStreamHonorsOptions(t *testing.T) {
+	dec := NewDecoder(NewScanner(strings.NewReader(`[{"int": 1, "bogus": 2}]`)))
+	dec.DisallowUnknownFields()
+	err := dec.UnmarshalStream(func(ed *ElementDecoder) error {
+		var out atype
+		return ed.Decode(&out)
+	})
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if _, ok := err.(*UnknownFieldError); !ok {
+		t.Fatalf("err = %#v, want *UnknownFieldError", err)
+	}
+}
+
+func TestUnmarshalStreamSkip(t *testing.T) {
+	var got []int
+	s := NewScanner(strings.NewReader(`[1, {"a": 2}, 3]`))
+	i := 0
+	err := UnmarshalStream(s, func(dec *ElementDecoder) error {
+		i++
+		if i == 2 {
+			dec.Skip()
+			return nil
+		}
+		var x int
+		if err := dec.Decode(&x); err != nil {
+			return err
+		}
+		got = append(got, x)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Fatalf("got %v, want [1 3]", got)
+	}
+}
+
+// BenchmarkDecodeSlice decodes a large array into a []int, which grows and
+// retains every element for the lifetime of the call.
+func BenchmarkDecodeSlice(b *testing.B) {
+	in := bigArrayJSON(100000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var out []int
+		s := NewScanner(strings.NewReader(in))
+		if err := Unmarshal(s, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkUnmarshalStream decodes the same array element by element,
+// retaining only the current element, so memory stays roughly constant as
+// the array grows instead of scaling linearly like BenchmarkDecodeSlice.
+func BenchmarkUnmarshalStream(b *testing.B) {
+	in := bigArrayJSON(100000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var x int
+		s := NewScanner(strings.NewReader(in))
+		err := UnmarshalStream(s, func(dec *ElementDecoder) error {
+			return dec.Decode(&x)
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
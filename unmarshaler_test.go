@@ -0,0 +1,101 @@
+// Copyright 2013 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type upperString struct {
+	S string
+}
+
+func (u *upperString) UnmarshalJSON(raw []byte) error {
+	u.S = strings.ToUpper(string(raw))
+	return nil
+}
+
+type csvInts struct {
+	vals []int
+}
+
+func (c *csvInts) UnmarshalText(raw []byte) error {
+	for _, part := range strings.Split(string(raw), ",") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return err
+		}
+		c.vals = append(c.vals, n)
+	}
+	return nil
+}
+
+func TestDecodeUnmarshaler(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"s":"hello"}`))
+	var out struct {
+		S upperString
+	}
+	if err := Unmarshal(s, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.S.S != `"HELLO"` {
+		t.Errorf("S.S = %q, want %q", out.S.S, `"HELLO"`)
+	}
+}
+
+func TestDecodeUnmarshalerNilPointerField(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"p":"hi"}`))
+	var out struct {
+		P *upperString
+	}
+	if err := Unmarshal(s, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.P == nil {
+		t.Fatal("P not allocated")
+	}
+	if out.P.S != `"HI"` {
+		t.Errorf("P.S = %q, want %q", out.P.S, `"HI"`)
+	}
+}
+
+func TestDecodeUnmarshalerEscapesRawValue(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"s":"he said \"hi\" to me"}`))
+	var out struct {
+		S upperString
+	}
+	if err := Unmarshal(s, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := `"HE SAID \"HI\" TO ME"`
+	if out.S.S != want {
+		t.Errorf("S.S = %s, want %s", out.S.S, want)
+	}
+	var roundTripped string
+	if err := UnmarshalWith(NewScanner(strings.NewReader(out.S.S)), &roundTripped, UnmarshalOptions{}); err != nil {
+		t.Fatalf("raw value is not valid JSON: %v", err)
+	}
+}
+
+func TestDecodeTextUnmarshaler(t *testing.T) {
+	s := NewScanner(strings.NewReader(`{"c":"1,2,3"}`))
+	var out struct {
+		C csvInts
+	}
+	if err := Unmarshal(s, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1, 2, 3}
+	if len(out.C.vals) != len(want) {
+		t.Fatalf("vals = %v, want %v", out.C.vals, want)
+	}
+	for i := range want {
+		if out.C.vals[i] != want[i] {
+			t.Fatalf("vals = %v, want %v", out.C.vals, want)
+		}
+	}
+}